@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const dockerHubRegistry = "registry-1.docker.io"
+
+// dockerConfig is the small subset of ~/.docker/config.json pmw understands: per-registry
+// basic auth, stored as base64("user:pass") under auths.<registry>.auth.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+}
+
+// splitImageRef splits an image reference like "ghcr.io/owner/repo" or "redis" into its
+// registry host and repository path, applying Docker Hub's implicit "library/" namespace and
+// default registry the same way the docker CLI does.
+func splitImageRef(image string) (registry, repository string) {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.Contains(parts[0], ".") || strings.Contains(parts[0], ":") || parts[0] == "localhost") {
+		return parts[0], parts[1]
+	}
+	if len(parts) == 1 {
+		return dockerHubRegistry, "library/" + image
+	}
+	return dockerHubRegistry, image
+}
+
+// dockerConfigAuth looks up a basic-auth header value for the given registry from
+// ~/.docker/config.json, if present.
+func dockerConfigAuth(registry string) (string, bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".docker", "config.json"))
+	if err != nil {
+		return "", false
+	}
+	var cfg dockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", false
+	}
+	entry, ok := cfg.Auths[registry]
+	if !ok || entry.Auth == "" {
+		return "", false
+	}
+	return "Basic " + entry.Auth, true
+}
+
+// dockerHubAnonymousToken fetches a short-lived anonymous pull token from Docker Hub's auth
+// service, which registry-1.docker.io requires even for public images.
+func dockerHubAnonymousToken(repository string) (string, error) {
+	url := fmt.Sprintf("https://auth.docker.io/token?service=registry.docker.io&scope=repository:%s:pull", repository)
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("docker hub auth returned status: %d", resp.StatusCode)
+	}
+	var tokenResponse struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", err
+	}
+	return tokenResponse.Token, nil
+}
+
+// resolveImageDigest resolves image:tag to its content digest (image@sha256:...) by sending a
+// manifest HEAD request per the OCI distribution spec (GET /v2/<name>/manifests/<tag>) and
+// reading back the Docker-Content-Digest response header.
+func resolveImageDigest(image, tag string) (string, error) {
+	registry, repository := splitImageRef(image)
+	url := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, tag)
+
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.oci.image.index.v1+json",
+	}, ", "))
+
+	if auth, ok := dockerConfigAuth(registry); ok {
+		req.Header.Set("Authorization", auth)
+	} else if registry == dockerHubRegistry {
+		if token, err := dockerHubAnonymousToken(repository); err == nil {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("registry HEAD manifest for %s/%s:%s returned status: %d", registry, repository, tag, resp.StatusCode)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry response for %s/%s:%s had no Docker-Content-Digest header", registry, repository, tag)
+	}
+	return digest, nil
+}
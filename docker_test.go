@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestSplitImageRef(t *testing.T) {
+	cases := []struct {
+		image        string
+		wantRegistry string
+		wantRepo     string
+	}{
+		{image: "redis", wantRegistry: dockerHubRegistry, wantRepo: "library/redis"},
+		{image: "library/redis", wantRegistry: dockerHubRegistry, wantRepo: "library/redis"},
+		{image: "myorg/myimage", wantRegistry: dockerHubRegistry, wantRepo: "myorg/myimage"},
+		{image: "ghcr.io/owner/repo", wantRegistry: "ghcr.io", wantRepo: "owner/repo"},
+		{image: "localhost/owner/repo", wantRegistry: "localhost", wantRepo: "owner/repo"},
+		{image: "localhost:5000/owner/repo", wantRegistry: "localhost:5000", wantRepo: "owner/repo"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			registry, repo := splitImageRef(c.image)
+			if registry != c.wantRegistry || repo != c.wantRepo {
+				t.Errorf("splitImageRef(%q) = (%q, %q), want (%q, %q)", c.image, registry, repo, c.wantRegistry, c.wantRepo)
+			}
+		})
+	}
+}
@@ -14,7 +14,6 @@ import (
 	"time"
     "os/signal"
 	"syscall"
-	"sort"
 )
 
 var ColorReset = "\033[0m" 
@@ -29,13 +28,30 @@ var ColorWhite = "\033[97m"
 
 // AllowedOrgs: Keeping the list of organisation where we accept version tagging for their workflow
 // AcceptedMapping: List of previously accepted versions & their commit hashes.
+// TrustedSigners: List of GitHub account logins that GitHub itself resolved a verified signature
+// to (via the commits API), not free-text tagger/committer names, which are attacker-controlled
+// and prove nothing about who actually holds the signing key.
+// GitHost is the git host ref resolution talks to (github.com if unset); point it at a
+// self-hosted GitLab/Gitea/Gerrit instance to pin actions/workflows from there instead.
+// Origin caches owner/repo@tag -> resolved commit SHA across runs (resolver.go), the same way
+// AcceptedMapping does for interactively-accepted pins, so resolving the same ref from hundreds
+// of workflows in a monorepo only hits the network once. An entry is sticky once cached and never
+// re-validated against the remote - to pick up a newly-released version under a floating prefix
+// (e.g. "v4" -> a new "v4.2.0"), remove its key here. See README.md's "Caching" section for the
+// full tradeoff; master/main are exempt from this cache entirely (resolver.go) since they're
+// expected to move on every run.
 type Config struct {
-	AllowedOrgs     []string          `json:"allowedOrgs"`
-	AcceptedMapping map[string]string `json:"acceptedMapping"`
+	AllowedOrgs         []string          `json:"allowedOrgs"`
+	AcceptedMapping     map[string]string `json:"acceptedMapping"`
+	TrustedSigners      []string          `json:"trustedSigners"`
+	GitHost             string            `json:"gitHost,omitempty"`
+	LocalWorkflowHashes map[string]string `json:"localWorkflowHashes"`
+	Origin              map[string]string `json:"origin,omitempty"`
 }
 
 var configFile = ".github/pmw-config.json"
 var verbose = false
+var requireSigned = false
 var config Config
 
 func loadConfig() error {
@@ -85,6 +101,13 @@ type GitTagResponse struct {
 		Type string `json:"type"`
 		Sha  string `json:"sha"`
 	} `json:"object"`
+	Tagger struct {
+		Name string `json:"name"`
+	} `json:"tagger"`
+	Verification struct {
+		Verified bool   `json:"verified"`
+		Reason   string `json:"reason"`
+	} `json:"verification"`
 }
 
 // Resolving tag reference via github API
@@ -105,6 +128,112 @@ func resolveTag(owner, repo, sha string) (string, error) {
 	return tagResponse.Object.Sha, nil
 }
 
+// isTrustedSigner returns true if the given GitHub login is in the TrustedSigners list.
+func isTrustedSigner(login string) bool {
+	if login == "" {
+		return false
+	}
+	for _, signer := range config.TrustedSigners {
+		if strings.EqualFold(login, signer) {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitResponse is the subset of GitHub's REST commits API (not the lower-level git data API)
+// we need. Unlike the git data API's tagger/committer name, `author.login` here is GitHub's own
+// resolution of the verified signature to an account - it cannot be spoofed by setting a name
+// field on the tag/commit object.
+type CommitResponse struct {
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Commit struct {
+		Verification struct {
+			Verified bool   `json:"verified"`
+			Reason   string `json:"reason"`
+		} `json:"verification"`
+	} `json:"commit"`
+}
+
+// resolveSignedIdentity asks GitHub which account, if any, it associated with the verified
+// signature on commitSha. This is the identity actually worth trusting: GitHub only fills in
+// author.login when the commit's signature verifies against a key registered to that account.
+func resolveSignedIdentity(owner, repo, commitSha string) (login string, verified bool, err error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", owner, repo, commitSha)
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", false, fmt.Errorf("GitHub API returned status: %d", resp.StatusCode)
+	}
+	var c CommitResponse
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		return "", false, err
+	}
+	return c.Author.Login, c.Commit.Verification.Verified, nil
+}
+
+// verifySignature inspects the tag reference for owner/repo and reports a human-readable
+// signature status ("signed by X", "signed by X (untrusted signer)", or "unsigned") along with
+// whether the tag/commit is trustworthy enough to satisfy --require-signed. Trust is keyed off
+// the GitHub account GitHub itself ties to the verified signature (resolveSignedIdentity), never
+// off the tag's free-text tagger name, which anyone holding any registered key can set to
+// whatever they like.
+func verifySignature(owner, repo, tag, commitSha string) (status string, trusted bool) {
+	refUrl := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/tags/%s", owner, repo, tag)
+	resp, err := http.Get(refUrl)
+	if err != nil || resp.StatusCode != 200 {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return "unsigned", false
+	}
+	var ref GitRefResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&ref)
+	resp.Body.Close()
+	if decodeErr != nil {
+		return "unsigned", false
+	}
+
+	if ref.Object.Type == "tag" {
+		tagObj, err := resolveTagObject(owner, repo, ref.Object.Sha)
+		if err == nil && tagObj.Verification.Verified {
+			login, verified, idErr := resolveSignedIdentity(owner, repo, commitSha)
+			if idErr == nil && verified && login != "" {
+				if isTrustedSigner(login) {
+					return fmt.Sprintf("signed by %s", login), true
+				}
+				return fmt.Sprintf("signed by %s (untrusted signer)", login), false
+			}
+			return "signed (signer could not be resolved)", false
+		}
+	}
+
+	return "unsigned", false
+}
+
+// resolveTagObject fetches the raw annotated tag object, including its verification status.
+func resolveTagObject(owner, repo, tagSha string) (GitTagResponse, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/tags/%s", owner, repo, tagSha)
+	resp, err := http.Get(url)
+	if err != nil {
+		return GitTagResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return GitTagResponse{}, fmt.Errorf("GitHub API returned status: %d", resp.StatusCode)
+	}
+	var tagResponse GitTagResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tagResponse); err != nil {
+		return GitTagResponse{}, err
+	}
+	return tagResponse, nil
+}
+
 
 func compareVersions(v1, v2 string) int {
     parts1 := strings.Split(v1, ".")
@@ -132,73 +261,18 @@ func compareVersions(v1, v2 string) int {
 
 // Turn out when user put in v2, github workflow will find the latest version that has prefix "v2".. .so it could be v2.x.x, v2.x
 // As such we will need to go through all tags and compare the versions.
+// This is now backed by resolveLatestVersionTag (resolver.go), which lists tags via
+// `git ls-remote` instead of paginating the GitHub REST API.
 func findLatestVersionTag(owner, repo, prefix string) (string, error) {
-    url := fmt.Sprintf("https://api.github.com/repos/%s/%s/tags", owner, repo)
-    resp, err := http.Get(url)
-    if err != nil {
-        return "", err
-    }
-    defer resp.Body.Close()
-    if resp.StatusCode != 200 {
-        return "", fmt.Errorf("GitHub API returned status: %d", resp.StatusCode)
-    }
-    var tags []struct {
-        Name   string `json:"name"`
-        Commit struct {
-            Sha string `json:"sha"`
-        } `json:"commit"`
-    }
-    if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
-        return "", err
-    }
-    var filtered []string
-    for _, t := range tags {
-        if strings.HasPrefix(t.Name, prefix) {
-            filtered = append(filtered, t.Name)
-        }
-    }
-    if len(filtered) == 0 {
-        return "", fmt.Errorf("no tags found with prefix %s", prefix)
-    }
-    // Sort filtered tags in descending order (latest first) using semantic version comparison.
-    sort.Slice(filtered, func(i, j int) bool {
-        v1 := strings.TrimPrefix(filtered[i], "v")
-        v2 := strings.TrimPrefix(filtered[j], "v")
-        return compareVersions(v1, v2) > 0
-    })
-    latestTag := filtered[0]
-    for _, t := range tags {
-        if t.Name == latestTag {
-            return t.Commit.Sha, nil
-        }
-    }
-    return "", fmt.Errorf("could not resolve latest tag for prefix %s", prefix)
+	return resolveLatestVersionTag(owner, repo, prefix)
 }
 
 // Getting commit sha for version tags, iterate through nested tag if neccessary
 // for workflow pin to master, we just get the hash of master branch
+// This is now backed by resolveRef (resolver.go), which talks to the remote directly via
+// `git ls-remote` instead of paginating the GitHub REST API.
 func getCommitSha(owner, repo, tag string) (string, error) {
-	var orginUrl string
-	if tag == "master" {
-		orginUrl = fmt.Sprintf("https://api.github.com/repos/%s/%s/git/ref/heads/master", owner, repo)
-	} else if tag == "main" {
-		orginUrl = fmt.Sprintf("https://api.github.com/repos/%s/%s/git/ref/heads/main", owner, repo)
-	}  else {
-		return findLatestVersionTag(owner, repo, tag)
-	}
-	resp, err := http.Get(orginUrl)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("GitHub API returned status: %d", resp.StatusCode)
-	}
-	var tagResponse GitTagResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tagResponse); err != nil {
-		return "", err
-	}
-	return tagResponse.Object.Sha, nil
+	return resolveRef(owner, repo, tag)
 }
 
 // isAllowedOrg returns true if the given owner is in the allowed organizations list.
@@ -215,6 +289,10 @@ func isAllowedOrg(owner string) bool {
 //   uses: owner/repo@tag
 var usageRegex = regexp.MustCompile(`uses:\s*([^/]+)/([^@]+)@(\S+)`)
 
+// rawUsesRegex captures the raw value of a `uses:` line before we know whether it's a GitHub
+// action, a docker:// image, or a local reusable workflow path.
+var rawUsesRegex = regexp.MustCompile(`uses:\s*(\S+)`)
+
 // processFile go through each line in workflow
 func processFile(filePath string) error {
     if verbose == true {
@@ -228,6 +306,57 @@ func processFile(filePath string) error {
 	changed := false
 
 	for i, line := range lines {
+		if rawMatch := rawUsesRegex.FindStringSubmatch(line); rawMatch != nil {
+			usesValue := rawMatch[1]
+			handled := false
+			for _, p := range pinners {
+				if !p.Matches(usesValue) {
+					continue
+				}
+				handled = true
+				pinned, description, ok, err := p.Resolve(usesValue)
+				if err != nil {
+					fmt.Printf("Error resolving %s: %v\n", usesValue, err)
+					break
+				}
+				if description != "" {
+					fmt.Println(description)
+				}
+				if ok {
+					leadingWhitespace := ""
+					for _, r := range line {
+						if r == ' ' || r == '\t' {
+							leadingWhitespace += string(r)
+						} else {
+							break
+						}
+					}
+					newUsage := fmt.Sprintf("%suses: %s", leadingWhitespace, pinned)
+
+					fmt.Printf("[.]In File: %s\n"+ColorRed+"[-]Old: %s\n"+ColorReset+ColorBlue+"[+]New: %s\n"+ColorReset+"Choose option: (y)es, (n)o, (q)uit: ", filePath, strings.TrimSpace(line), newUsage)
+					reader := bufio.NewReader(os.Stdin)
+					answer, _ := reader.ReadString('\n')
+					switch strings.TrimSpace(strings.ToLower(answer)) {
+					case "y":
+						lines[i] = newUsage
+						changed = true
+					case "q":
+						fmt.Println("Quitting processing...")
+						if err := saveConfig(); err != nil {
+							fmt.Printf("Error saving config: %v\n", err)
+						}
+						os.Exit(0)
+					default:
+						fmt.Println("Skipping.")
+					}
+				}
+				break
+			}
+			if handled {
+				continue
+			}
+		}
+
 		matches := usageRegex.FindStringSubmatch(line)
 		if matches == nil {
 			continue
@@ -263,7 +392,20 @@ func processFile(filePath string) error {
 			    checkUrl = fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", owner, repo, tag)
 			}
             newUsage := fmt.Sprintf("uses: %s/%s@%s %s", owner, repo, commitSha, versionTag)
-			fmt.Printf("[.]In File: %s\n"+ColorRed+"[-]Old: %s (Check URL: %s)\n"+ColorReset+ColorBlue+"[+]New: %s\n"+ColorReset+"Choose option: (y)es, (n)o, (a)dd to allowedOrgs, (q)uit: ", filePath, strings.TrimSpace(line), checkUrl, newUsage)
+
+            // Floating tags like "v4" don't have their own ref/signature; resolve the concrete
+            // release tag (e.g. "v4.1.0") it points at before checking its signature.
+            concreteTag, err := resolveConcreteTag(owner, repo, tag)
+            if err != nil {
+                concreteTag = tag
+            }
+            sigStatus, sigTrusted := verifySignature(owner, repo, concreteTag, commitSha)
+            if requireSigned && !sigTrusted {
+                fmt.Printf(ColorRed+"[-] Refusing to pin %s: signature status is %q and --require-signed was set\n"+ColorReset, key, sigStatus)
+                continue
+            }
+
+			fmt.Printf("[.]In File: %s\n"+ColorRed+"[-]Old: %s (Check URL: %s, Signature: %s)\n"+ColorReset+ColorBlue+"[+]New: %s\n"+ColorReset+"Choose option: (y)es, (n)o, (a)dd to allowedOrgs, (q)uit: ", filePath, strings.TrimSpace(line), checkUrl, sigStatus, newUsage)
 
             reader := bufio.NewReader(os.Stdin)
             answer, _ := reader.ReadString('\n')
@@ -322,18 +464,35 @@ func processFile(filePath string) error {
 }
 
 func main() {
+	// `pmw verify` is a subcommand rather than a flag: it audits without mutating anything, so
+	// it gets its own mode before we even parse the usual pinning flags.
+	isVerify := len(os.Args) > 1 && os.Args[1] == "verify"
+	if isVerify {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	// Allow using config file but by default, we save our config right into .github folder.
     configPath := flag.String("c", ".github/pmw-config.json", "Path to configuration file")
     verboseMode := flag.Bool("v", false, "Verbose mode")
+    requireSignedMode := flag.Bool("require-signed", false, "Refuse to pin tags/commits that fail signature verification")
+    planMode := flag.Bool("plan", false, "Non-interactive: resolve every unpinned uses: line and print a report instead of prompting")
+    planFormat := flag.String("format", "json", "Report format for --plan: json or sarif")
+    applyPlanPath := flag.String("apply-plan", "", "Path to a plan file (from --plan) to apply unattended, without prompting")
     flag.Parse()
     configFile = *configPath
     verbose = *verboseMode
+    requireSigned = *requireSignedMode
 
 	if err := loadConfig(); err != nil {
 		fmt.Println("Error loading config:", err)
 		return
 	}
 
+	if isVerify {
+		runVerify()
+		return
+	}
+
 	// Catching Ctrl+C and SIGTERM and save config halfway
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -346,6 +505,28 @@ func main() {
 		os.Exit(0)
 	}()
 
+	if *applyPlanPath != "" {
+		if err := applyPlan(*applyPlanPath); err != nil {
+			fmt.Println("Error applying plan:", err)
+		}
+		if err := saveConfig(); err != nil {
+			fmt.Println("Error saving config:", err)
+		}
+		return
+	}
+
+	if *planMode {
+		plan, err := buildPlan()
+		if err != nil {
+			fmt.Println("Error building plan:", err)
+			return
+		}
+		if err := writePlan(plan, *planFormat, os.Stdout); err != nil {
+			fmt.Println("Error writing plan:", err)
+		}
+		return
+	}
+
 	err := filepath.Walk(".github/workflows", func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
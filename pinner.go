@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Pinner resolves a `uses:` reference that isn't a GitHub owner/repo@tag action into something
+// that can be pinned, and renders the replacement line. Docker images and local reusable
+// workflows both need their own resolution logic, but share this shape so processFile can treat
+// them uniformly.
+type Pinner interface {
+	// Name identifies this pinner in plan reports ("docker", "local").
+	Name() string
+	// Matches reports whether this pinner handles the given uses: value.
+	Matches(usesValue string) bool
+	// Resolve resolves usesValue (and reports a human-readable description of what changed) or
+	// returns ok=false if there's nothing to pin (e.g. already pinned, or unresolvable).
+	Resolve(usesValue string) (pinned string, description string, ok bool, err error)
+}
+
+var dockerUsesRegex = regexp.MustCompile(`^docker://(\S+):(\S+)$`)
+var localUsesRegex = regexp.MustCompile(`^(\./\S+)$`)
+var digestRegex = regexp.MustCompile(`@sha256:[0-9a-f]{64}$`)
+
+var pinners = []Pinner{
+	dockerPinner{},
+	localPinner{},
+}
+
+// dockerPinner resolves `uses: docker://image:tag` to `docker://image@sha256:...` by querying
+// the image's registry directly, following the OCI distribution spec.
+type dockerPinner struct{}
+
+func (dockerPinner) Name() string { return "docker" }
+
+func (dockerPinner) Matches(usesValue string) bool {
+	return dockerUsesRegex.MatchString(usesValue)
+}
+
+func (dockerPinner) Resolve(usesValue string) (string, string, bool, error) {
+	matches := dockerUsesRegex.FindStringSubmatch(usesValue)
+	image, tag := matches[1], matches[2]
+	if digestRegex.MatchString(usesValue) {
+		return "", "", false, nil // already pinned to a digest
+	}
+	digest, err := resolveImageDigest(image, tag)
+	if err != nil {
+		return "", "", false, err
+	}
+	pinned := fmt.Sprintf("docker://%s@%s", image, digest)
+	description := fmt.Sprintf("%s -> %s", usesValue, pinned)
+	return pinned, description, true, nil
+}
+
+// localPinner handles `uses: ./path/to/workflow.yml` reusable workflows. There's no remote SHA
+// to pin to, so instead it records the referenced file's git blob SHA and warns when that blob
+// SHA no longer matches what we recorded last time, i.e. the file changed underneath us.
+type localPinner struct{}
+
+func (localPinner) Name() string { return "local" }
+
+func (localPinner) Matches(usesValue string) bool {
+	return localUsesRegex.MatchString(usesValue)
+}
+
+func (localPinner) Resolve(usesValue string) (string, string, bool, error) {
+	matches := localUsesRegex.FindStringSubmatch(usesValue)
+	path := matches[1]
+	blobSha, err := gitBlobSha(path)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	if config.LocalWorkflowHashes == nil {
+		config.LocalWorkflowHashes = make(map[string]string)
+	}
+	previous, seen := config.LocalWorkflowHashes[path]
+	config.LocalWorkflowHashes[path] = blobSha
+
+	if seen && previous != blobSha {
+		return "", fmt.Sprintf("%s changed since last run (blob %s -> %s)", path, previous, blobSha), false, nil
+	}
+	return "", fmt.Sprintf("%s tracked at blob %s", path, blobSha), false, nil
+}
+
+// gitBlobSha computes the git blob SHA-1 of a file the same way `git hash-object` does, so the
+// recorded hash matches what `git cat-file` would report for that path.
+func gitBlobSha(path string) (string, error) {
+	if out, err := exec.Command("git", "hash-object", path).Output(); err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
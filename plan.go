@@ -0,0 +1,354 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PlanEntry describes a single unpinned `uses:` line that pmw --plan discovered, and what it
+// would be rewritten to if applied. Kind distinguishes GitHub actions ("action") from the
+// Pinner-backed kinds ("docker", "local"); Owner/Repo/ResolvedSha/IsFloatingBranch/AllowlistedOrg/
+// SignatureStatus/SignatureTrusted only apply to "action" entries, while NewUses/Note carry the
+// Pinner-backed ones.
+type PlanEntry struct {
+	File             string `json:"file"`
+	Line             int    `json:"line"`
+	Kind             string `json:"kind"`
+	Owner            string `json:"owner,omitempty"`
+	Repo             string `json:"repo,omitempty"`
+	OldRef           string `json:"oldRef"`
+	ResolvedSha      string `json:"resolvedSha,omitempty"`
+	IsFloatingBranch bool   `json:"isFloatingBranch,omitempty"`
+	AllowlistedOrg   bool   `json:"allowlistedOrg,omitempty"`
+	SignatureStatus  string `json:"signatureStatus,omitempty"`
+	SignatureTrusted bool   `json:"signatureTrusted,omitempty"`
+	NewUses          string `json:"newUses,omitempty"`
+	Note             string `json:"note,omitempty"`
+}
+
+// Plan is the top-level report produced by --plan and consumed by --apply-plan.
+type Plan struct {
+	Entries []PlanEntry `json:"entries"`
+}
+
+var semverTagRegex = regexp.MustCompile(`^v?\d+(\.\d+){0,2}$`)
+
+// isFloatingBranch reports whether tag looks like a moving branch ref (master/main, or anything
+// that isn't a semver-shaped tag) rather than a pinned release tag.
+func isFloatingBranch(tag string) bool {
+	if tag == "master" || tag == "main" {
+		return true
+	}
+	return !semverTagRegex.MatchString(tag)
+}
+
+// buildPlan walks .github/workflows and resolves every unpinned `uses:` line, without
+// prompting, into a Plan. This is the "propose" half of what processFile used to do in one loop.
+func buildPlan() (Plan, error) {
+	var plan Plan
+	err := filepath.Walk(".github/workflows", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(info.Name(), ".yml") && !strings.HasSuffix(info.Name(), ".yaml") {
+			return nil
+		}
+		entries, err := planFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error planning %s: %v\n", path, err)
+			return nil
+		}
+		plan.Entries = append(plan.Entries, entries...)
+		return nil
+	})
+	return plan, err
+}
+
+// planFile resolves every unpinned `uses:` line in a single workflow file into PlanEntries.
+func planFile(filePath string) ([]PlanEntry, error) {
+	inputBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(inputBytes), "\n")
+
+	var entries []PlanEntry
+	for i, line := range lines {
+		if rawMatch := rawUsesRegex.FindStringSubmatch(line); rawMatch != nil {
+			usesValue := rawMatch[1]
+			handledByPinner := false
+			for _, p := range pinners {
+				if !p.Matches(usesValue) {
+					continue
+				}
+				handledByPinner = true
+				pinned, description, ok, err := p.Resolve(usesValue)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error resolving %s: %v\n", usesValue, err)
+					break
+				}
+				if ok {
+					entries = append(entries, PlanEntry{
+						File:    filePath,
+						Line:    i + 1,
+						Kind:    p.Name(),
+						OldRef:  usesValue,
+						NewUses: pinned,
+					})
+				} else if description != "" {
+					entries = append(entries, PlanEntry{
+						File:   filePath,
+						Line:   i + 1,
+						Kind:   p.Name(),
+						OldRef: usesValue,
+						Note:   description,
+					})
+				}
+				break
+			}
+			if handledByPinner {
+				continue
+			}
+		}
+
+		matches := usageRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		owner, repo, tag := matches[1], matches[2], matches[3]
+		if regexp.MustCompile(`^[0-9a-f]{40}$`).MatchString(tag) {
+			continue
+		}
+
+		commitSha, err := getCommitSha(owner, repo, tag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error retrieving commit SHA for %s/%s@%s: %v\n", owner, repo, tag, err)
+			continue
+		}
+
+		// Floating tags like "v4" don't have their own ref/signature; resolve the concrete
+		// release tag (e.g. "v4.1.0") it points at before checking its signature, same as
+		// processFile.
+		concreteTag, err := resolveConcreteTag(owner, repo, tag)
+		if err != nil {
+			concreteTag = tag
+		}
+		sigStatus, sigTrusted := verifySignature(owner, repo, concreteTag, commitSha)
+
+		entries = append(entries, PlanEntry{
+			File:             filePath,
+			Line:             i + 1,
+			Kind:             "action",
+			Owner:            owner,
+			Repo:             repo,
+			OldRef:           tag,
+			ResolvedSha:      commitSha,
+			IsFloatingBranch: isFloatingBranch(tag),
+			AllowlistedOrg:   isAllowedOrg(owner),
+			SignatureStatus:  sigStatus,
+			SignatureTrusted: sigTrusted,
+		})
+	}
+	return entries, nil
+}
+
+// writePlan renders the plan as JSON (default) or SARIF (format == "sarif") to the given writer.
+func writePlan(plan Plan, format string, out *os.File) error {
+	switch format {
+	case "sarif":
+		return writeSarif(plan, out)
+	default:
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(append(data, '\n'))
+		return err
+	}
+}
+
+// Minimal SARIF 2.1.0 structures, just enough to report one rule ("unpinned-action") with one
+// result per PlanEntry so the report can be uploaded to GitHub code scanning.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+func writeSarif(plan Plan, out *os.File) error {
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "pmw",
+						InformationURI: "https://github.com/santrancisco/pmw",
+						Rules: []sarifRule{
+							{ID: "unpinned-action", Name: "UnpinnedGitHubAction"},
+							{ID: "unpinned-docker", Name: "UnpinnedDockerImage"},
+							{ID: "local-workflow-drift", Name: "LocalWorkflowDrift"},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, entry := range plan.Entries {
+		var ruleID, level, text string
+		switch entry.Kind {
+		case "docker":
+			ruleID, level = "unpinned-docker", "warning"
+			text = fmt.Sprintf("%s is not pinned to a digest (resolves to %s)", entry.OldRef, entry.NewUses)
+		case "local":
+			ruleID, level = "local-workflow-drift", "warning"
+			text = entry.Note
+		default:
+			ruleID, level = "unpinned-action", "warning"
+			if entry.IsFloatingBranch && !entry.AllowlistedOrg {
+				level = "error"
+			}
+			text = fmt.Sprintf("%s/%s@%s is not pinned to a commit SHA (resolves to %s)", entry.Owner, entry.Repo, entry.OldRef, entry.ResolvedSha)
+		}
+		log.Runs[0].Results = append(log.Runs[0].Results, sarifResult{
+			RuleID:  ruleID,
+			Level:   level,
+			Message: sarifMessage{Text: text},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: entry.File},
+						Region:           sarifRegion{StartLine: entry.Line},
+					},
+				},
+			},
+		})
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(data, '\n'))
+	return err
+}
+
+// applyPlan reads a previously reviewed plan file and rewrites the `uses:` lines it describes,
+// unattended. This is the "apply" half of what processFile used to do in one loop.
+func applyPlan(planPath string) error {
+	data, err := ioutil.ReadFile(planPath)
+	if err != nil {
+		return err
+	}
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return err
+	}
+
+	byFile := make(map[string][]PlanEntry)
+	for _, entry := range plan.Entries {
+		byFile[entry.File] = append(byFile[entry.File], entry)
+	}
+
+	for filePath, entries := range byFile {
+		inputBytes, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(string(inputBytes), "\n")
+		for _, entry := range entries {
+			if entry.Kind == "local" {
+				continue // informational only, there's no line to rewrite
+			}
+			if entry.Kind == "action" && entry.AllowlistedOrg {
+				continue // owner is on AllowedOrgs; processFile never pins these either
+			}
+			if entry.Kind == "action" && requireSigned && !entry.SignatureTrusted {
+				fmt.Printf(ColorRed+"[-] Refusing to pin %s/%s@%s: signature status is %q and --require-signed was set\n"+ColorReset, entry.Owner, entry.Repo, entry.OldRef, entry.SignatureStatus)
+				continue
+			}
+			idx := entry.Line - 1
+			if idx < 0 || idx >= len(lines) {
+				fmt.Printf("Skipping %s:%d, line out of range\n", filePath, entry.Line)
+				continue
+			}
+			leadingWhitespace := ""
+			for _, r := range lines[idx] {
+				if r == ' ' || r == '\t' {
+					leadingWhitespace += string(r)
+				} else {
+					break
+				}
+			}
+			if entry.Kind == "docker" {
+				lines[idx] = fmt.Sprintf("%suses: %s", leadingWhitespace, entry.NewUses)
+				continue
+			}
+			versionTag := fmt.Sprintf("#%s", entry.OldRef)
+			lines[idx] = fmt.Sprintf("%suses: %s/%s@%s %s", leadingWhitespace, entry.Owner, entry.Repo, entry.ResolvedSha, versionTag)
+			config.AcceptedMapping[originKey(entry.Owner, entry.Repo, entry.OldRef)] = entry.ResolvedSha
+		}
+		newContent := strings.Join(lines, "\n")
+		if err := ioutil.WriteFile(filePath, []byte(newContent), 0644); err != nil {
+			return err
+		}
+		fmt.Printf(ColorGreen+"Updated file: %s\n"+ColorReset, filePath)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsFloatingBranch(t *testing.T) {
+	cases := []struct {
+		tag  string
+		want bool
+	}{
+		{tag: "master", want: true},
+		{tag: "main", want: true},
+		{tag: "v1", want: false},
+		{tag: "v1.2", want: false},
+		{tag: "v1.2.3", want: false},
+		{tag: "1.2.3", want: false},
+		{tag: "latest", want: true},
+		{tag: "v1.2.3-beta", want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.tag, func(t *testing.T) {
+			if got := isFloatingBranch(c.tag); got != c.want {
+				t.Errorf("isFloatingBranch(%q) = %v, want %v", c.tag, got, c.want)
+			}
+		})
+	}
+}
+
+// TestApplyPlanSkipsAllowlistedOrg verifies applyPlan never rewrites an "action" entry whose
+// owner is on AllowedOrgs, matching processFile's isAllowedOrg(owner) -> skip behaviour.
+func TestApplyPlanSkipsAllowlistedOrg(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = Config{AcceptedMapping: make(map[string]string)}
+
+	dir := t.TempDir()
+	wf := filepath.Join(dir, "ci.yml")
+	original := "steps:\n  - uses: trusted-org/action@v1\n"
+	if err := os.WriteFile(wf, []byte(original), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	plan := Plan{Entries: []PlanEntry{
+		{
+			File:           wf,
+			Line:           2,
+			Kind:           "action",
+			Owner:          "trusted-org",
+			Repo:           "action",
+			OldRef:         "v1",
+			ResolvedSha:    "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+			AllowlistedOrg: true,
+		},
+	}}
+	planPath := filepath.Join(dir, "plan.json")
+	planData, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent: %v", err)
+	}
+	if err := os.WriteFile(planPath, planData, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if err := applyPlan(planPath); err != nil {
+		t.Fatalf("applyPlan returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(wf)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !strings.Contains(string(got), "trusted-org/action@v1") {
+		t.Errorf("applyPlan rewrote an allowlisted-org entry: %s", got)
+	}
+}
+
+// TestApplyPlanRefusesUntrustedSignatureWhenRequireSigned verifies --require-signed is honoured
+// by applyPlan, not just the interactive processFile path.
+func TestApplyPlanRefusesUntrustedSignatureWhenRequireSigned(t *testing.T) {
+	savedConfig, savedRequireSigned := config, requireSigned
+	defer func() { config, requireSigned = savedConfig, savedRequireSigned }()
+	config = Config{AcceptedMapping: make(map[string]string)}
+	requireSigned = true
+
+	dir := t.TempDir()
+	wf := filepath.Join(dir, "ci.yml")
+	original := "steps:\n  - uses: some-org/action@v1\n"
+	if err := os.WriteFile(wf, []byte(original), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	plan := Plan{Entries: []PlanEntry{
+		{
+			File:             wf,
+			Line:             2,
+			Kind:             "action",
+			Owner:            "some-org",
+			Repo:             "action",
+			OldRef:           "v1",
+			ResolvedSha:      "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+			SignatureStatus:  "unsigned",
+			SignatureTrusted: false,
+		},
+	}}
+	planPath := filepath.Join(dir, "plan.json")
+	planData, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent: %v", err)
+	}
+	if err := os.WriteFile(planPath, planData, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if err := applyPlan(planPath); err != nil {
+		t.Fatalf("applyPlan returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(wf)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !strings.Contains(string(got), "some-org/action@v1") {
+		t.Errorf("applyPlan pinned an untrusted-signature entry under --require-signed: %s", got)
+	}
+}
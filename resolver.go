@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// defaultGitHost is used when Config.GitHost is unset, preserving the original GitHub-only
+// behaviour for existing configs.
+const defaultGitHost = "github.com"
+
+// remoteURLFor builds the git remote URL for owner/repo against the configured git host.
+// Everything below only cares about the final URL, so pointing GitHost at a GitLab/Gitea/Gerrit
+// instance works the same way, as long as it's reachable over plain https.
+func remoteURLFor(owner, repo string) string {
+	host := config.GitHost
+	if host == "" {
+		host = defaultGitHost
+	}
+	return fmt.Sprintf("https://%s/%s/%s.git", host, owner, repo)
+}
+
+// lsRemote shells out to `git ls-remote` and returns a ref -> sha map. This replaces the old
+// per-call GitHub REST requests: it talks the native git protocol directly to the remote, so it
+// works against any git host and never touches GitHub's (rate-limited) API.
+func lsRemote(remoteURL string, args ...string) (map[string]string, error) {
+	cmdArgs := append([]string{"ls-remote", remoteURL}, args...)
+	out, err := exec.Command("git", cmdArgs...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-remote %s failed: %w", remoteURL, err)
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		refs[fields[1]] = fields[0]
+	}
+	return refs, nil
+}
+
+// originKey builds the "owner/repo@tag" key used for Config.AcceptedMapping lookups.
+func originKey(owner, repo, tag string) string {
+	return fmt.Sprintf("%s/%s@%s", owner, repo, tag)
+}
+
+// resolveRef resolves owner/repo@tag to a commit SHA, consulting the persistent Origin cache
+// before ever shelling out to ls-remote. master/main are deliberately never cached: they're
+// branch refs that are expected to keep moving, and a cache entry here never invalidates itself
+// (see Config.Origin), so caching them would mean "master" silently pins to whatever commit it
+// happened to be the first time pmw ran and never advances again.
+func resolveRef(owner, repo, tag string) (string, error) {
+	if tag == "master" || tag == "main" {
+		return resolveRefUncached(owner, repo, tag)
+	}
+
+	key := originKey(owner, repo, tag)
+	if sha, ok := config.Origin[key]; ok {
+		return sha, nil
+	}
+
+	sha, err := resolveRefUncached(owner, repo, tag)
+	if err != nil {
+		return "", err
+	}
+	cacheOrigin(key, sha)
+	return sha, nil
+}
+
+// resolveRefUncached does the actual ls-remote work resolveRef caches.
+func resolveRefUncached(owner, repo, tag string) (string, error) {
+	remoteURL := remoteURLFor(owner, repo)
+
+	if tag == "master" || tag == "main" {
+		refs, err := lsRemote(remoteURL, "refs/heads/"+tag)
+		if err != nil {
+			return "", err
+		}
+		sha, ok := refs["refs/heads/"+tag]
+		if !ok {
+			return "", fmt.Errorf("branch %s not found on %s", tag, remoteURL)
+		}
+		return sha, nil
+	}
+
+	return resolveLatestVersionTag(owner, repo, tag)
+}
+
+// cacheOrigin records key -> sha in the persistent Origin cache, initialising the map on first
+// use. Callers still rely on saveConfig to flush it to pmw-config.json.
+func cacheOrigin(key, sha string) {
+	if config.Origin == nil {
+		config.Origin = make(map[string]string)
+	}
+	config.Origin[key] = sha
+}
+
+// resolveLatestVersionTag lists tags via ls-remote (instead of paginating the GitHub tags API)
+// and picks the highest semver tag matching the given prefix, the same matching behaviour as
+// before.
+func resolveLatestVersionTag(owner, repo, prefix string) (string, error) {
+	remoteURL := remoteURLFor(owner, repo)
+
+	refs, err := lsRemote(remoteURL, "--tags", "refs/tags/*")
+	if err != nil {
+		return "", err
+	}
+	_, sha, err := selectLatestTag(refs, prefix)
+	return sha, err
+}
+
+// resolveConcreteTag resolves a possibly-floating tag (e.g. "v4") to the concrete tag name it
+// currently points at (e.g. "v4.1.0"), using the same selection resolveLatestVersionTag does.
+// Branch refs (master/main) have no concrete tag, so they're returned unchanged.
+func resolveConcreteTag(owner, repo, tag string) (string, error) {
+	if tag == "master" || tag == "main" {
+		return tag, nil
+	}
+	remoteURL := remoteURLFor(owner, repo)
+	refs, err := lsRemote(remoteURL, "--tags", "refs/tags/*")
+	if err != nil {
+		return "", err
+	}
+	name, _, err := selectLatestTag(refs, tag)
+	return name, err
+}
+
+// selectLatestTag picks the highest semver tag matching prefix out of a ref -> sha map shaped
+// like lsRemote's output (refs/tags/<name> and, for annotated tags, refs/tags/<name>^{}), and
+// returns both the matched tag name and its commit SHA.
+func selectLatestTag(refs map[string]string, prefix string) (name string, sha string, err error) {
+	var names []string
+	nameToSha := make(map[string]string)
+	for ref, sha := range refs {
+		if strings.HasSuffix(ref, "^{}") {
+			continue // handled below, once we know which names already exist
+		}
+		name := strings.TrimPrefix(ref, "refs/tags/")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		names = append(names, name)
+		nameToSha[name] = sha
+	}
+	// Annotated tags also advertise a dereferenced "name^{}" ref pointing at the underlying
+	// commit; prefer that commit SHA over the tag object's own SHA.
+	for ref, sha := range refs {
+		if !strings.HasSuffix(ref, "^{}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(ref, "refs/tags/"), "^{}")
+		if _, ok := nameToSha[name]; ok {
+			nameToSha[name] = sha
+		}
+	}
+	if len(names) == 0 {
+		return "", "", fmt.Errorf("no tags found with prefix %s", prefix)
+	}
+
+	latest := names[0]
+	for _, name := range names[1:] {
+		if compareVersions(strings.TrimPrefix(name, "v"), strings.TrimPrefix(latest, "v")) > 0 {
+			latest = name
+		}
+	}
+	return latest, nameToSha[latest], nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		v1, v2 string
+		want   int
+	}{
+		{v1: "1.2.3", v2: "1.2.3", want: 0},
+		{v1: "1.2.4", v2: "1.2.3", want: 1},
+		{v1: "1.2.3", v2: "1.2.4", want: -1},
+		{v1: "2.0.0", v2: "1.9.9", want: 1},
+		{v1: "1.2", v2: "1.2.0", want: 0},
+		{v1: "1.10.0", v2: "1.9.0", want: 1},
+	}
+
+	for _, c := range cases {
+		if got := compareVersions(c.v1, c.v2); got != c.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", c.v1, c.v2, got, c.want)
+		}
+	}
+}
+
+func TestSelectLatestTag(t *testing.T) {
+	refs := map[string]string{
+		"refs/tags/v1.0.0":    "sha-v1.0.0",
+		"refs/tags/v1.2.0":    "sha-v1.2.0",
+		"refs/tags/v1.2.0^{}": "sha-v1.2.0-commit",
+		"refs/tags/v2.0.0":    "sha-v2.0.0",
+		"refs/tags/other":     "sha-other",
+	}
+
+	name, sha, err := selectLatestTag(refs, "v1")
+	if err != nil {
+		t.Fatalf("selectLatestTag returned error: %v", err)
+	}
+	if name != "v1.2.0" {
+		t.Errorf("selectLatestTag(prefix=v1) name = %q, want v1.2.0", name)
+	}
+	// An annotated tag's dereferenced "^{}" sha should win over the tag object's own sha.
+	if sha != "sha-v1.2.0-commit" {
+		t.Errorf("selectLatestTag(prefix=v1) sha = %q, want sha-v1.2.0-commit", sha)
+	}
+
+	if _, _, err := selectLatestTag(refs, "v9"); err == nil {
+		t.Error("selectLatestTag(prefix=v9) expected an error for no matching tags, got nil")
+	}
+}
+
+func TestResolveRefUsesOriginCache(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	config = Config{Origin: map[string]string{
+		originKey("acme", "widgets", "v1.2.3"): "cached-sha",
+	}}
+
+	sha, err := resolveRef("acme", "widgets", "v1.2.3")
+	if err != nil {
+		t.Fatalf("resolveRef returned error: %v", err)
+	}
+	if sha != "cached-sha" {
+		t.Errorf("resolveRef() = %q, want cached-sha (should not have hit the network)", sha)
+	}
+}
+
+// TestResolveRefNeverCachesBranchRefs verifies master/main always resolve live, even with a
+// (deliberately stale) Origin entry present, since caching a branch pointer would stop it ever
+// advancing.
+func TestResolveRefNeverCachesBranchRefs(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	config = Config{Origin: map[string]string{
+		originKey("acme", "widgets", "master"): "stale-cached-sha",
+	}}
+
+	sha, err := resolveRef("acme", "widgets", "master")
+	if err == nil && sha == "stale-cached-sha" {
+		t.Error("resolveRef(\"master\") returned the stale Origin cache entry, want a live resolution")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pinnedLineRegex matches the lines pmw itself writes: a SHA pin plus the "#tag" (or
+// "#master-YYYY-MM-DD") comment recording what it was pinned from.
+var pinnedLineRegex = regexp.MustCompile(`uses:\s*([^/]+)/([^@]+)@([0-9a-f]{40})\s+#(\S+)`)
+
+type compareResponse struct {
+	Status  string `json:"status"`
+	AheadBy int    `json:"ahead_by"`
+	Commits []struct {
+		Sha    string `json:"sha"`
+		Commit struct {
+			Message string `json:"message"`
+		} `json:"commit"`
+	} `json:"commits"`
+}
+
+// refFromTagComment recovers the tag/branch name that was originally pinned from the comment
+// pmw writes next to the SHA, e.g. "#master-2024-01-01" -> "master", "#v4.1.0" -> "v4.1.0".
+func refFromTagComment(comment string) string {
+	if strings.HasPrefix(comment, "master-") {
+		return "master"
+	}
+	if strings.HasPrefix(comment, "main-") {
+		return "main"
+	}
+	return comment
+}
+
+// compareCommits asks the GitHub API how base and head relate, giving us both the ahead/behind
+// status and the list of commits between them. This is GitHub's REST compare endpoint
+// specifically - there's no equivalent shape on GitLab/Gitea/Gerrit - so unlike ref resolution
+// (which goes through remoteURLFor and respects Config.GitHost), this refuses to run against a
+// configured non-GitHub host instead of silently mis-querying github.com and reporting every pin
+// as unverifiable.
+func compareCommits(owner, repo, base, head string) (compareResponse, error) {
+	if config.GitHost != "" && config.GitHost != defaultGitHost {
+		return compareResponse{}, fmt.Errorf("pmw verify only supports github.com; configured gitHost is %q", config.GitHost)
+	}
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s", owner, repo, base, head)
+	resp, err := http.Get(url)
+	if err != nil {
+		return compareResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return compareResponse{}, fmt.Errorf("GitHub API returned status: %d", resp.StatusCode)
+	}
+	var result compareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return compareResponse{}, err
+	}
+	return result, nil
+}
+
+// verifyPin checks whether a pinned SHA is still reachable from its named tag/branch on the
+// remote, i.e. sha is an ancestor of the current tip of ref. It returns drift=true when the
+// upstream ref was force-moved away from the commit we pinned (the tj-actions/changed-files
+// attack pattern).
+func verifyPin(owner, repo, sha, ref string) (drift bool, detail string, err error) {
+	// Deliberately bypasses resolveRef's Origin cache: verify exists to catch a ref that moved
+	// out from under us, so it must always ask the remote for the live tip, never a cached one.
+	currentSha, err := resolveRefUncached(owner, repo, ref)
+	if err != nil {
+		return false, "", err
+	}
+	if currentSha == sha {
+		return false, fmt.Sprintf("%s/%s@%s is up to date with %s", owner, repo, sha, ref), nil
+	}
+
+	cmp, err := compareCommits(owner, repo, sha, currentSha)
+	if err != nil {
+		return false, "", err
+	}
+	return classifyDrift(owner, repo, sha, ref, cmp)
+}
+
+// classifyDrift turns a GitHub compare response between a pinned sha and the ref's current tip
+// into a drift verdict: "ahead"/"identical" means sha is still an ancestor of ref (no drift),
+// anything else (typically "diverged" or "behind") means the ref moved away from sha entirely.
+func classifyDrift(owner, repo, sha, ref string, cmp compareResponse) (drift bool, detail string, err error) {
+	switch cmp.Status {
+	case "ahead", "identical":
+		return false, fmt.Sprintf("%s/%s@%s is still an ancestor of %s (%d commits behind)", owner, repo, sha, ref, cmp.AheadBy), nil
+	default:
+		var lines []string
+		for _, c := range cmp.Commits {
+			message := strings.SplitN(c.Commit.Message, "\n", 2)[0]
+			lines = append(lines, fmt.Sprintf("  %s %s", c.Sha[:12], message))
+		}
+		detail := fmt.Sprintf("%s/%s@%s is NOT reachable from %s (status=%s); tag was likely retagged/force-moved", owner, repo, sha, ref, cmp.Status)
+		if len(lines) > 0 {
+			detail += "\n" + strings.Join(lines, "\n")
+		}
+		return true, detail, nil
+	}
+}
+
+// runVerify implements `pmw verify`: it audits every already-pinned uses: line without mutating
+// any file, and exits non-zero if drift is found so it can be run as a scheduled workflow.
+func runVerify() {
+	driftFound := false
+
+	err := filepath.Walk(".github/workflows", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(info.Name(), ".yml") && !strings.HasSuffix(info.Name(), ".yaml") {
+			return nil
+		}
+
+		inputBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for i, line := range strings.Split(string(inputBytes), "\n") {
+			matches := pinnedLineRegex.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+			owner, repo, sha, comment := matches[1], matches[2], matches[3], matches[4]
+			ref := refFromTagComment(comment)
+
+			drift, detail, err := verifyPin(owner, repo, sha, ref)
+			if err != nil {
+				fmt.Printf(ColorYellow+"[?] %s:%d could not verify %s/%s@%s: %v\n"+ColorReset, path, i+1, owner, repo, sha, err)
+				continue
+			}
+			if drift {
+				driftFound = true
+				fmt.Printf(ColorRed+"[!] %s:%d %s\n"+ColorReset, path, i+1, detail)
+			} else if verbose {
+				fmt.Printf(ColorGreen+"[+] %s:%d %s\n"+ColorReset, path, i+1, detail)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println("Error walking directory:", err)
+		os.Exit(1)
+	}
+
+	if driftFound {
+		os.Exit(1)
+	}
+}
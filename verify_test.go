@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestClassifyDrift(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    string
+		aheadBy   int
+		wantDrift bool
+	}{
+		{name: "ahead means sha is still an ancestor", status: "ahead", aheadBy: 3, wantDrift: false},
+		{name: "identical means nothing moved", status: "identical", aheadBy: 0, wantDrift: false},
+		{name: "diverged means the tag was force-moved", status: "diverged", wantDrift: true},
+		{name: "behind means the tag was force-moved", status: "behind", wantDrift: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmp := compareResponse{Status: c.status, AheadBy: c.aheadBy}
+			drift, detail, err := classifyDrift("owner", "repo", "deadbeef", "v1.2.3", cmp)
+			if err != nil {
+				t.Fatalf("classifyDrift returned error: %v", err)
+			}
+			if drift != c.wantDrift {
+				t.Errorf("classifyDrift(status=%s) drift = %v, want %v", c.status, drift, c.wantDrift)
+			}
+			if detail == "" {
+				t.Errorf("classifyDrift(status=%s) returned empty detail", c.status)
+			}
+		})
+	}
+}
+
+// TestCompareCommitsRefusesNonGitHubHost verifies compareCommits fails loudly instead of silently
+// querying github.com when GitHost points somewhere the compare API doesn't exist.
+func TestCompareCommitsRefusesNonGitHubHost(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	config = Config{GitHost: "gitlab.example.com"}
+	if _, err := compareCommits("owner", "repo", "deadbeef", "beefdead"); err == nil {
+		t.Error("compareCommits with a non-GitHub GitHost returned nil error, want a refusal")
+	}
+}